@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// resolveFailOn picks the effective --fail-on category, preferring the CLI
+// flag over config.yml and defaulting to "any".
+func resolveFailOn(configVal, flagVal string) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	if configVal != "" {
+		return configVal
+	}
+	return "any"
+}
+
+// resolveReporter picks the effective --reporter, preferring the CLI flag
+// over config.yml. Empty means "none" (just the selected --format output).
+func resolveReporter(configVal, flagVal string) string {
+	if flagVal != "" {
+		return flagVal
+	}
+	return configVal
+}
+
+// countFailingDiffs counts the diffs that should make the run fail under
+// failOn ("any", "added", "removed", or "changed").
+func countFailingDiffs(diffs []DiffRecord, failOn string) int {
+	if failOn == "" || failOn == "any" {
+		return len(diffs)
+	}
+	count := 0
+	for _, d := range diffs {
+		if string(d.Category) == failOn {
+			count++
+		}
+	}
+	return count
+}
+
+// TestCase is a reporter-agnostic pass/fail result, rendered as one JUnit
+// <testcase> or one TAP line.
+type TestCase struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// AssertionResult is the outcome of checking one Assertion against a
+// CompareResult's diffs.
+type AssertionResult struct {
+	Assertion Assertion
+	Passed    bool
+	Message   string
+}
+
+// evaluateAssertions checks every configured assertion against the diffs
+// found by the comparison core.
+func evaluateAssertions(diffs []DiffRecord, assertions []Assertion) []AssertionResult {
+	results := make([]AssertionResult, len(assertions))
+	for i, a := range assertions {
+		results[i] = evaluateAssertion(a, diffs)
+	}
+	return results
+}
+
+func evaluateAssertion(a Assertion, diffs []DiffRecord) AssertionResult {
+	hasDiff := false
+	for _, d := range diffs {
+		if d.Path == a.Path {
+			hasDiff = true
+			break
+		}
+	}
+
+	switch a.Rule {
+	case AssertMustDiffer:
+		if hasDiff {
+			return AssertionResult{a, true, fmt.Sprintf("%s differs as expected", a.Path)}
+		}
+		return AssertionResult{a, false, fmt.Sprintf("expected %s to differ but it matched", a.Path)}
+	case AssertMustEqual:
+		if !hasDiff {
+			return AssertionResult{a, true, fmt.Sprintf("%s is equal as expected", a.Path)}
+		}
+		return AssertionResult{a, false, fmt.Sprintf("expected %s to be equal but it differs", a.Path)}
+	default:
+		return AssertionResult{a, false, fmt.Sprintf("unknown assertion rule %q for path %s", a.Rule, a.Path)}
+	}
+}
+
+func assertionTestCases(results []AssertionResult) []TestCase {
+	cases := make([]TestCase, len(results))
+	for i, r := range results {
+		cases[i] = TestCase{
+			Name:    fmt.Sprintf("%s %s", r.Assertion.Path, r.Assertion.Rule),
+			Passed:  r.Passed,
+			Message: r.Message,
+		}
+	}
+	return cases
+}
+
+// topLevelKeyTestCases builds one TestCase per top-level key found in either
+// input, used for JUnit/TAP reporting when assertion mode is off.
+func topLevelKeyTestCases(obj1, obj2 interface{}, diffs []DiffRecord) []TestCase {
+	keys := map[string]struct{}{}
+	if m1, ok := obj1.(map[string]interface{}); ok {
+		for k := range m1 {
+			keys[k] = struct{}{}
+		}
+	}
+	if m2, ok := obj2.(map[string]interface{}); ok {
+		for k := range m2 {
+			keys[k] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	cases := make([]TestCase, 0, len(names))
+	for _, name := range names {
+		var messages []string
+		for _, d := range diffs {
+			if d.Path == name || strings.HasPrefix(d.Path, name+".") || strings.HasPrefix(d.Path, name+"[") {
+				messages = append(messages, fmt.Sprintf("%s: %s != %s", d.Path, d.OldValue, d.NewValue))
+			}
+		}
+		cases = append(cases, TestCase{Name: name, Passed: len(messages) == 0, Message: strings.Join(messages, "\n")})
+	}
+	return cases
+}
+
+// writeReport renders cases in the selected CI reporter format. An empty or
+// unrecognized reporter is a no-op, since CSV output is already handled by
+// the normal --format flow.
+func writeReport(reporter string, cases []TestCase) error {
+	switch reporter {
+	case "junit":
+		data, err := buildJUnitXML("json-compare", cases)
+		if err != nil {
+			return fmt.Errorf("error building JUnit report: %w", err)
+		}
+		return os.WriteFile("junit.xml", data, 0o644)
+	case "tap":
+		return os.WriteFile("results.tap", []byte(buildTAP(cases)), 0o644)
+	default:
+		return nil
+	}
+}
+
+type junitTestsuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:",chardata"`
+}
+
+func buildJUnitXML(suiteName string, cases []TestCase) ([]byte, error) {
+	suite := junitTestsuite{Name: suiteName, Tests: len(cases)}
+	for _, c := range cases {
+		tc := junitTestCase{Name: c.Name, ClassName: "json-compare"}
+		if !c.Passed {
+			suite.Failures++
+			tc.Failure = &junitFailure{Message: c.Message}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	body, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func buildTAP(cases []TestCase) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "1..%d\n", len(cases))
+	for i, c := range cases {
+		status := "ok"
+		if !c.Passed {
+			status = "not ok"
+		}
+		fmt.Fprintf(&sb, "%s %d - %s\n", status, i+1, c.Name)
+		if !c.Passed && c.Message != "" {
+			fmt.Fprintf(&sb, "  ---\n  message: %s\n  ...\n", c.Message)
+		}
+	}
+	return sb.String()
+}
+
+func anyFailed(cases []TestCase) bool {
+	for _, c := range cases {
+		if !c.Passed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveFailOn(t *testing.T) {
+	tests := []struct {
+		name, configVal, flagVal, want string
+	}{
+		{"flag wins over config", "added", "removed", "removed"},
+		{"config used when flag empty", "changed", "", "changed"},
+		{"defaults to any", "", "", "any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveFailOn(tt.configVal, tt.flagVal); got != tt.want {
+				t.Errorf("resolveFailOn(%q, %q) = %q, want %q", tt.configVal, tt.flagVal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveReporter(t *testing.T) {
+	tests := []struct {
+		name, configVal, flagVal, want string
+	}{
+		{"flag wins over config", "tap", "junit", "junit"},
+		{"config used when flag empty", "tap", "", "tap"},
+		{"empty when neither set", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveReporter(tt.configVal, tt.flagVal); got != tt.want {
+				t.Errorf("resolveReporter(%q, %q) = %q, want %q", tt.configVal, tt.flagVal, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountFailingDiffs(t *testing.T) {
+	diffs := []DiffRecord{
+		{Path: "a", Category: DiffAdded},
+		{Path: "b", Category: DiffRemoved},
+		{Path: "c", Category: DiffChanged},
+		{Path: "d", Category: DiffChanged},
+	}
+
+	tests := []struct {
+		failOn string
+		want   int
+	}{
+		{"any", 4},
+		{"", 4},
+		{"added", 1},
+		{"removed", 1},
+		{"changed", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			if got := countFailingDiffs(diffs, tt.failOn); got != tt.want {
+				t.Errorf("countFailingDiffs(..., %q) = %d, want %d", tt.failOn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateAssertion(t *testing.T) {
+	diffs := []DiffRecord{{Path: "metadata.version", Category: DiffChanged}}
+
+	tests := []struct {
+		name       string
+		assertion  Assertion
+		wantPassed bool
+	}{
+		{"must_differ passes when path has a diff", Assertion{Path: "metadata.version", Rule: AssertMustDiffer}, true},
+		{"must_differ fails when path has no diff", Assertion{Path: "metadata.name", Rule: AssertMustDiffer}, false},
+		{"must_equal passes when path has no diff", Assertion{Path: "metadata.name", Rule: AssertMustEqual}, true},
+		{"must_equal fails when path has a diff", Assertion{Path: "metadata.version", Rule: AssertMustEqual}, false},
+		{"unknown rule fails", Assertion{Path: "metadata.name", Rule: "bogus"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateAssertion(tt.assertion, diffs)
+			if got.Passed != tt.wantPassed {
+				t.Errorf("evaluateAssertion(%+v) passed = %v, want %v (message: %s)", tt.assertion, got.Passed, tt.wantPassed, got.Message)
+			}
+		})
+	}
+}
+
+func TestAssertionTestCases(t *testing.T) {
+	results := []AssertionResult{
+		{Assertion: Assertion{Path: "a", Rule: AssertMustEqual}, Passed: true, Message: "a is equal as expected"},
+		{Assertion: Assertion{Path: "b", Rule: AssertMustDiffer}, Passed: false, Message: "expected b to differ but it matched"},
+	}
+	cases := assertionTestCases(results)
+	if len(cases) != 2 {
+		t.Fatalf("expected 2 cases, got %d", len(cases))
+	}
+	if cases[0].Name != "a must_equal" || !cases[0].Passed {
+		t.Errorf("unexpected case[0]: %+v", cases[0])
+	}
+	if cases[1].Name != "b must_differ" || cases[1].Passed {
+		t.Errorf("unexpected case[1]: %+v", cases[1])
+	}
+}
+
+func TestTopLevelKeyTestCases(t *testing.T) {
+	obj1 := map[string]interface{}{"name": "a", "count": 1}
+	obj2 := map[string]interface{}{"name": "a", "count": 2, "extra": "new"}
+	diffs := []DiffRecord{
+		{Path: "count", OldValue: "1", NewValue: "2", Category: DiffChanged},
+		{Path: "extra", OldValue: "", NewValue: "new", Category: DiffAdded},
+	}
+
+	cases := topLevelKeyTestCases(obj1, obj2, diffs)
+
+	byName := map[string]TestCase{}
+	for _, c := range cases {
+		byName[c.Name] = c
+	}
+
+	if len(cases) != 3 {
+		t.Fatalf("expected 3 cases (name, count, extra), got %d: %+v", len(cases), cases)
+	}
+	if !byName["name"].Passed {
+		t.Errorf("expected 'name' to pass (no diff), got %+v", byName["name"])
+	}
+	if byName["count"].Passed {
+		t.Errorf("expected 'count' to fail (has a diff), got %+v", byName["count"])
+	}
+	if byName["extra"].Passed {
+		t.Errorf("expected 'extra' to fail (has a diff), got %+v", byName["extra"])
+	}
+}
+
+func TestBuildJUnitXMLIsWellFormed(t *testing.T) {
+	cases := []TestCase{
+		{Name: "name", Passed: true},
+		{Name: "count", Passed: false, Message: "count: 1 != 2"},
+	}
+
+	data, err := buildJUnitXML("json-compare", cases)
+	if err != nil {
+		t.Fatalf("buildJUnitXML returned error: %v", err)
+	}
+
+	var suite junitTestsuite
+	if err := xml.Unmarshal(data, &suite); err != nil {
+		t.Fatalf("buildJUnitXML output is not well-formed XML: %v\n%s", err, data)
+	}
+	if suite.Tests != 2 {
+		t.Errorf("Tests = %d, want 2", suite.Tests)
+	}
+	if suite.Failures != 1 {
+		t.Errorf("Failures = %d, want 1", suite.Failures)
+	}
+	if len(suite.TestCases) != 2 {
+		t.Fatalf("expected 2 testcases, got %d", len(suite.TestCases))
+	}
+	if suite.TestCases[1].Failure == nil || suite.TestCases[1].Failure.Message != "count: 1 != 2" {
+		t.Errorf("expected failure message on the failing case, got %+v", suite.TestCases[1])
+	}
+}
+
+func TestBuildTAPIsWellFormed(t *testing.T) {
+	cases := []TestCase{
+		{Name: "name", Passed: true},
+		{Name: "count", Passed: false, Message: "count: 1 != 2"},
+	}
+
+	tap := buildTAP(cases)
+	lines := strings.Split(strings.TrimRight(tap, "\n"), "\n")
+
+	if lines[0] != "1..2" {
+		t.Errorf("expected TAP plan line '1..2', got %q", lines[0])
+	}
+	if lines[1] != "ok 1 - name" {
+		t.Errorf("expected 'ok 1 - name', got %q", lines[1])
+	}
+	if lines[2] != "not ok 2 - count" {
+		t.Errorf("expected 'not ok 2 - count', got %q", lines[2])
+	}
+	if !strings.Contains(tap, "message: count: 1 != 2") {
+		t.Errorf("expected failure message block in TAP output, got:\n%s", tap)
+	}
+}
+
+func TestAnyFailed(t *testing.T) {
+	if anyFailed([]TestCase{{Passed: true}, {Passed: true}}) {
+		t.Errorf("expected anyFailed to be false when all cases pass")
+	}
+	if !anyFailed([]TestCase{{Passed: true}, {Passed: false}}) {
+		t.Errorf("expected anyFailed to be true when any case fails")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	cases := []TestCase{{Name: "a", Passed: true}}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("os.Chdir: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	if err := writeReport("junit", cases); err != nil {
+		t.Fatalf("writeReport(junit) returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "junit.xml")); err != nil {
+		t.Errorf("expected junit.xml to be written: %v", err)
+	}
+
+	if err := writeReport("tap", cases); err != nil {
+		t.Fatalf("writeReport(tap) returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "results.tap")); err != nil {
+		t.Errorf("expected results.tap to be written: %v", err)
+	}
+
+	if err := writeReport("", cases); err != nil {
+		t.Fatalf("writeReport(\"\") should be a no-op, got error: %v", err)
+	}
+}
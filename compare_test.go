@@ -0,0 +1,150 @@
+package main
+
+import "testing"
+
+func TestCompareObjectsIgnorePaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		ignorePaths []string
+		obj1, obj2  interface{}
+		wantMatch   bool
+	}{
+		{
+			name:        "ignored top-level field differs but run still matches",
+			ignorePaths: []string{"generated_at"},
+			obj1:        map[string]interface{}{"generated_at": "t1", "name": "a"},
+			obj2:        map[string]interface{}{"generated_at": "t2", "name": "a"},
+			wantMatch:   true,
+		},
+		{
+			name:        "glob covers nested annotations",
+			ignorePaths: []string{"metadata.annotations.*"},
+			obj1: map[string]interface{}{"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"checksum": "abc"},
+			}},
+			obj2: map[string]interface{}{"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"checksum": "def"},
+			}},
+			wantMatch: true,
+		},
+		{
+			name:        "unrelated field still reported",
+			ignorePaths: []string{"generated_at"},
+			obj1:        map[string]interface{}{"generated_at": "t1", "name": "a"},
+			obj2:        map[string]interface{}{"generated_at": "t2", "name": "b"},
+			wantMatch:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := CompareOptions{IgnorePaths: tt.ignorePaths}
+			_, result := compareObjects(tt.obj1, tt.obj2, cfg)
+			if got := result.FalseCount == 0; got != tt.wantMatch {
+				t.Errorf("FalseCount=%d, Diffs=%v, want match=%v", result.FalseCount, result.Diffs, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestCompareObjectsNumericTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance NumericTolerance
+		v1, v2    float64
+		wantMatch bool
+	}{
+		{"exact match with no tolerance configured", NumericTolerance{}, 1.0, 1.0, true},
+		{"absolute tolerance covers small drift", NumericTolerance{Absolute: 0.01}, 1.0, 1.005, true},
+		{"absolute tolerance rejects large drift", NumericTolerance{Absolute: 0.01}, 1.0, 1.5, false},
+		{"relative tolerance covers proportional drift", NumericTolerance{Relative: 0.1}, 100.0, 105.0, true},
+		{"relative tolerance rejects disproportionate drift", NumericTolerance{Relative: 0.1}, 100.0, 200.0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := CompareOptions{NumericTolerance: tt.tolerance}
+			obj1 := map[string]interface{}{"value": tt.v1}
+			obj2 := map[string]interface{}{"value": tt.v2}
+			_, result := compareObjects(obj1, obj2, cfg)
+			if got := result.FalseCount == 0; got != tt.wantMatch {
+				t.Errorf("FalseCount=%d, want match=%v", result.FalseCount, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestCompareObjectsArrayModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		arrayKeys  map[string]string
+		obj1, obj2 interface{}
+		wantMatch  bool
+	}{
+		{
+			name:      "ordered mode reports reordering as a diff",
+			mode:      ArrayCompareOrdered,
+			obj1:      map[string]interface{}{"items": []interface{}{"a", "b"}},
+			obj2:      map[string]interface{}{"items": []interface{}{"b", "a"}},
+			wantMatch: false,
+		},
+		{
+			name:      "set mode ignores reordering",
+			mode:      ArrayCompareSet,
+			obj1:      map[string]interface{}{"items": []interface{}{"a", "b"}},
+			obj2:      map[string]interface{}{"items": []interface{}{"b", "a"}},
+			wantMatch: true,
+		},
+		{
+			name:      "keyed mode matches elements by identity field regardless of order",
+			mode:      ArrayCompareKeyed,
+			arrayKeys: map[string]string{"users": "id"},
+			obj1: map[string]interface{}{"users": []interface{}{
+				map[string]interface{}{"id": "1", "name": "alice"},
+				map[string]interface{}{"id": "2", "name": "bob"},
+			}},
+			obj2: map[string]interface{}{"users": []interface{}{
+				map[string]interface{}{"id": "2", "name": "bob"},
+				map[string]interface{}{"id": "1", "name": "alice"},
+			}},
+			wantMatch: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := CompareOptions{ArrayCompare: tt.mode, ArrayKeys: tt.arrayKeys}
+			_, result := compareObjects(tt.obj1, tt.obj2, cfg)
+			if got := result.FalseCount == 0; got != tt.wantMatch {
+				t.Errorf("FalseCount=%d, Diffs=%v, want match=%v", result.FalseCount, result.Diffs, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestCompareObjectsTypeCoercion(t *testing.T) {
+	tests := []struct {
+		name         string
+		typeCoercion bool
+		v1, v2       interface{}
+		wantMatch    bool
+	}{
+		{"string and number differ without coercion", false, "1", float64(1), false},
+		{"string and number match with coercion", true, "1", float64(1), true},
+		{"bool and string differ without coercion", false, "true", true, false},
+		{"bool and string match with coercion", true, "true", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := CompareOptions{TypeCoercion: tt.typeCoercion}
+			obj1 := map[string]interface{}{"value": tt.v1}
+			obj2 := map[string]interface{}{"value": tt.v2}
+			_, result := compareObjects(obj1, obj2, cfg)
+			if got := result.FalseCount == 0; got != tt.wantMatch {
+				t.Errorf("FalseCount=%d, want match=%v", result.FalseCount, tt.wantMatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestLoadInputSideSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.json")
+	writeTestFile(t, path, `{"name":"widget"}`)
+
+	got, err := loadInputSide(path, "")
+	if err != nil {
+		t.Fatalf("loadInputSide returned error: %v", err)
+	}
+	want := map[string]interface{}{"name": "widget"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadInputSide(...) = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadInputSideGlobConcat(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.json"), `{"id":1}`)
+	writeTestFile(t, filepath.Join(dir, "b.json"), `{"id":2}`)
+
+	got, err := loadInputSide(filepath.Join(dir, "*.json"), "")
+	if err != nil {
+		t.Fatalf("loadInputSide returned error: %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"id": float64(1)},
+		map[string]interface{}{"id": float64(2)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadInputSide(...) = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadInputSideGlobDeepMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.json"), `{"id":1,"tags":["x"]}`)
+	writeTestFile(t, filepath.Join(dir, "b.json"), `{"id":2,"tags":["y"]}`)
+
+	got, err := loadInputSide(filepath.Join(dir, "*.json"), "deep_merge")
+	if err != nil {
+		t.Fatalf("loadInputSide returned error: %v", err)
+	}
+	want := map[string]interface{}{
+		"id":   float64(2),
+		"tags": []interface{}{"x", "y"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadInputSide(...) = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadInputSideGlobMatchesNoFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := loadInputSide(filepath.Join(dir, "*.json"), ""); err == nil {
+		t.Fatalf("expected an error for a glob matching no files")
+	}
+}
+
+func TestDeepMergeAll(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []interface{}
+		want interface{}
+	}{
+		{
+			name: "scalar conflicts let the later document win",
+			objs: []interface{}{
+				map[string]interface{}{"a": float64(1), "b": float64(1)},
+				map[string]interface{}{"b": float64(2), "c": float64(3)},
+			},
+			want: map[string]interface{}{"a": float64(1), "b": float64(2), "c": float64(3)},
+		},
+		{
+			name: "arrays at the same path are appended",
+			objs: []interface{}{
+				map[string]interface{}{"tags": []interface{}{"a"}},
+				map[string]interface{}{"tags": []interface{}{"b"}},
+			},
+			want: map[string]interface{}{"tags": []interface{}{"a", "b"}},
+		},
+		{
+			name: "nested maps merge recursively",
+			objs: []interface{}{
+				map[string]interface{}{"meta": map[string]interface{}{"x": float64(1)}},
+				map[string]interface{}{"meta": map[string]interface{}{"y": float64(2)}},
+			},
+			want: map[string]interface{}{"meta": map[string]interface{}{"x": float64(1), "y": float64(2)}},
+		},
+		{
+			name: "single document returns unchanged",
+			objs: []interface{}{map[string]interface{}{"a": float64(1)}},
+			want: map[string]interface{}{"a": float64(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deepMergeAll(tt.objs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("deepMergeAll(...) = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectRelativeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.json"), "{}")
+	writeTestFile(t, filepath.Join(dir, "nested", "b.json"), "{}")
+
+	got, err := collectRelativeFiles(dir)
+	if err != nil {
+		t.Fatalf("collectRelativeFiles returned error: %v", err)
+	}
+
+	want := map[string]struct{}{
+		"a.json":                          {},
+		filepath.Join("nested", "b.json"): {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectRelativeFiles(...) = %#v, want %#v", got, want)
+	}
+}
+
+func TestCompareDirectories(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir1, "same.json"), `{"a":1}`)
+	writeTestFile(t, filepath.Join(dir2, "same.json"), `{"a":1}`)
+
+	writeTestFile(t, filepath.Join(dir1, "diff.json"), `{"a":1}`)
+	writeTestFile(t, filepath.Join(dir2, "diff.json"), `{"a":2}`)
+
+	writeTestFile(t, filepath.Join(dir1, "only1.json"), `{"a":1}`)
+	writeTestFile(t, filepath.Join(dir2, "only2.json"), `{"a":1}`)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("os.Getwd: %v", err)
+	}
+	runDir := t.TempDir()
+	if err := os.Chdir(runDir); err != nil {
+		t.Fatalf("os.Chdir: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	config := &Config{}
+	aggregate, cases, err := compareDirectories(dir1, dir2, config)
+	if err != nil {
+		t.Fatalf("compareDirectories returned error: %v", err)
+	}
+
+	if aggregate.FalseCount == 0 {
+		t.Fatalf("expected the aggregate to report at least one difference")
+	}
+
+	byName := map[string]TestCase{}
+	for _, c := range cases {
+		byName[c.Name] = c
+	}
+	if !byName["same.json"].Passed {
+		t.Errorf("expected same.json to pass, got %+v", byName["same.json"])
+	}
+	if byName["diff.json"].Passed {
+		t.Errorf("expected diff.json to fail, got %+v", byName["diff.json"])
+	}
+	if byName["only1.json"].Passed {
+		t.Errorf("expected only1.json (present in dir1 only) to fail, got %+v", byName["only1.json"])
+	}
+	if byName["only2.json"].Passed {
+		t.Errorf("expected only2.json (present in dir2 only) to fail, got %+v", byName["only2.json"])
+	}
+
+	if _, err := os.Stat(filepath.Join(runDir, comparisonReportsDir, "summary.csv")); err != nil {
+		t.Errorf("expected summary.csv to be written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runDir, comparisonReportsDir, sanitizeReportName("diff.json")+".csv")); err != nil {
+		t.Errorf("expected a per-pair report for diff.json: %v", err)
+	}
+}
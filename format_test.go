@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		data     []byte
+		want     fileFormat
+	}{
+		{"json extension wins regardless of content", "file.json", []byte("key = 1"), formatJSON},
+		{"yaml extension", "file.yaml", []byte("key: 1"), formatYAML},
+		{"yml extension", "file.yml", []byte("key: 1"), formatYAML},
+		{"toml extension", "file.toml", []byte("key = 1"), formatTOML},
+		{"uppercase extension", "file.JSON", []byte("{}"), formatJSON},
+		{"no extension falls back to sniffing JSON", "file", []byte(`{"a":1}`), formatJSON},
+		{"no extension falls back to sniffing TOML", "file", []byte("key = 1"), formatTOML},
+		{"no extension falls back to sniffing YAML", "file", []byte("key: 1"), formatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat(tt.filePath, tt.data); got != tt.want {
+				t.Errorf("detectFormat(%q, %q) = %q, want %q", tt.filePath, tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want fileFormat
+	}{
+		{"empty content", []byte(""), formatYAML},
+		{"whitespace-only content", []byte("   \n  "), formatYAML},
+		{"leading brace is JSON", []byte(`{"a":1}`), formatJSON},
+		{"leading bracket is JSON", []byte(`[1,2,3]`), formatJSON},
+		{"key = value is TOML", []byte("name = \"widget\"\n"), formatTOML},
+		{"leading '[' is sniffed as JSON even for a TOML section header", []byte("[server]\nport = 8080\n"), formatJSON},
+		{"plain mapping is YAML", []byte("name: widget\ncount: 2\n"), formatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffFormat(tt.data); got != tt.want {
+				t.Errorf("sniffFormat(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikeTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"key = value", []byte("name = \"widget\"\n"), true},
+		{"section header", []byte("[server]\nport = 8080\n"), true},
+		{"leading comment then key = value", []byte("# comment\nname = \"widget\"\n"), true},
+		{"yaml mapping is not TOML", []byte("name: widget\n"), false},
+		{"yaml key with equals in value is not TOML", []byte("expr: a=b\n"), false},
+		{"blank content is not TOML", []byte("\n\n"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksLikeTOML(tt.data); got != tt.want {
+				t.Errorf("looksLikeTOML(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecodedValue(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{
+			name: "map[interface{}]interface{} becomes map[string]interface{}",
+			in:   map[interface{}]interface{}{"key": "value"},
+			want: map[string]interface{}{"key": "value"},
+		},
+		{
+			name: "nested map keys are stringified recursively",
+			in: map[interface{}]interface{}{
+				"outer": map[interface{}]interface{}{"inner": 1},
+			},
+			want: map[string]interface{}{
+				"outer": map[string]interface{}{"inner": float64(1)},
+			},
+		},
+		{"int widens to float64", int(3), float64(3)},
+		{"int64 widens to float64", int64(3), float64(3)},
+		{"uint64 widens to float64", uint64(3), float64(3)},
+		{"slice elements are normalized", []interface{}{int(1), int(2)}, []interface{}{float64(1), float64(2)}},
+		{"strings pass through unchanged", "hello", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeDecodedValue(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeDecodedValue(%#v) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeYAMLDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want interface{}
+	}{
+		{
+			name: "single document decodes as-is",
+			data: []byte("name: widget\ncount: 2\n"),
+			want: map[string]interface{}{"name": "widget", "count": float64(2)},
+		},
+		{
+			name: "multiple documents merge with later keys winning",
+			data: []byte("name: widget\ncount: 1\n---\ncount: 2\nextra: new\n"),
+			want: map[string]interface{}{"name": "widget", "count": float64(2), "extra": "new"},
+		},
+		{
+			name: "empty input decodes to nil",
+			data: []byte(""),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeYAMLDocuments("file.yaml", tt.data)
+			if err != nil {
+				t.Fatalf("decodeYAMLDocuments returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeYAMLDocuments(...) = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeYAMLDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []interface{}
+		want interface{}
+	}{
+		{
+			name: "later map keys override earlier ones",
+			docs: []interface{}{
+				map[string]interface{}{"a": 1, "b": 1},
+				map[string]interface{}{"b": 2, "c": 3},
+			},
+			want: map[string]interface{}{"a": 1, "b": 2, "c": 3},
+		},
+		{
+			name: "non-map document falls back to last-one-wins",
+			docs: []interface{}{
+				map[string]interface{}{"a": 1},
+				"just a string",
+			},
+			want: "just a string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeYAMLDocuments(tt.docs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeYAMLDocuments(...) = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
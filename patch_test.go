@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateAndApplyPatchRoundTrip(t *testing.T) {
+	obj1 := map[string]interface{}{
+		"name":  "widget",
+		"count": float64(2),
+		"tags":  []interface{}{"a", "b"},
+	}
+	obj2 := map[string]interface{}{
+		"name":  "widget",
+		"count": float64(5),
+		"tags":  []interface{}{"a", "b", "c"},
+		"extra": "new",
+	}
+
+	patch := generateJSONPatch(obj1, obj2)
+	if len(patch) == 0 {
+		t.Fatalf("generateJSONPatch returned no ops for differing inputs")
+	}
+
+	got, err := applyPatch(deepCopyJSON(obj1), patch)
+	if err != nil {
+		t.Fatalf("applyPatch returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, obj2) {
+		t.Fatalf("round trip mismatch:\n got:  %#v\n want: %#v", got, obj2)
+	}
+}
+
+func TestGenerateJSONPatchArrayLengthMismatchDoesNotPanic(t *testing.T) {
+	obj1 := map[string]interface{}{"items": []interface{}{float64(1), float64(2)}}
+	obj2 := map[string]interface{}{"items": []interface{}{float64(1), float64(2), float64(3)}}
+
+	patch := generateJSONPatch(obj1, obj2)
+	if len(patch) != 1 || patch[0].Op != "replace" || patch[0].Path != "/items" {
+		t.Fatalf("expected a single replace op at /items, got %#v", patch)
+	}
+
+	got, err := applyPatch(deepCopyJSON(obj1), patch)
+	if err != nil {
+		t.Fatalf("applyPatch returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, obj2) {
+		t.Fatalf("round trip mismatch:\n got:  %#v\n want: %#v", got, obj2)
+	}
+}
+
+func TestGenerateJSONDiffArrayLengthMismatchDoesNotPanic(t *testing.T) {
+	obj1 := map[string]interface{}{"items": []interface{}{float64(1), float64(2)}}
+	obj2 := map[string]interface{}{"items": []interface{}{float64(1), float64(2), float64(3)}}
+
+	diff := generateJSONDiff(obj1, obj2)
+	out, ok := diff.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected generateJSONDiff to return a map, got %#v", diff)
+	}
+	items, ok := out["items"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected items diff to be an __old/__new leaf, got %#v", out["items"])
+	}
+	if items["__old"] == nil || items["__new"] == nil {
+		t.Fatalf("expected __old/__new leaves to be populated, got %#v", items)
+	}
+}
+
+func TestApplyPatchRejectsOutOfRangeArrayIndex(t *testing.T) {
+	doc := map[string]interface{}{"items": []interface{}{float64(1), float64(2)}}
+
+	for _, op := range []string{"replace", "remove"} {
+		t.Run(op, func(t *testing.T) {
+			patch := []JSONPatchOp{{Op: op, Path: "/items/2", Value: float64(9)}}
+			if _, err := applyPatch(deepCopyJSON(doc), patch); err == nil {
+				t.Fatalf("expected an error for %s at one-past-the-end index, got nil", op)
+			}
+		})
+	}
+}
+
+func TestApplyPatchAddAtLengthAppends(t *testing.T) {
+	doc := map[string]interface{}{"items": []interface{}{float64(1), float64(2)}}
+	patch := []JSONPatchOp{{Op: "add", Path: "/items/2", Value: float64(3)}}
+
+	got, err := applyPatch(deepCopyJSON(doc), patch)
+	if err != nil {
+		t.Fatalf("applyPatch returned error: %v", err)
+	}
+	want := map[string]interface{}{"items": []interface{}{float64(1), float64(2), float64(3)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+// deepCopyJSON round-trips v through canonicalJSON so applyPatch can mutate
+// it without corrupting the original test fixture.
+func deepCopyJSON(v interface{}) interface{} {
+	var copy interface{}
+	if err := json.Unmarshal([]byte(canonicalJSON(v)), &copy); err != nil {
+		panic(err)
+	}
+	return copy
+}
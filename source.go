@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// isSourceURI reports whether a file_name_1/file_name_2 value is a URI (it
+// contains "://") rather than a plain local path. Any scheme counts here;
+// sourceLoaderFor accepts only http(s), s3 and file.
+func isSourceURI(spec string) bool {
+	return strings.Contains(spec, "://")
+}
+
+func sourceScheme(uri string) string {
+	if idx := strings.Index(uri, "://"); idx != -1 {
+		return uri[:idx]
+	}
+	return ""
+}
+
+// SourceLoader fetches the raw bytes behind a source URI. Implementations
+// are looked up by scheme in sourceLoaderFor.
+type SourceLoader interface {
+	Load(uri string) (io.ReadCloser, error)
+}
+
+// RetryConfig bounds how a SourceLoader fetch is retried on failure.
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+var defaultRetryConfig = RetryConfig{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond}
+
+// loadSourceBytes fetches the full contents behind a http(s)/s3/file URI,
+// retrying every failed fetch (including non-200 responses) with exponential
+// backoff.
+func loadSourceBytes(uri string) ([]byte, error) {
+	loader, err := sourceLoaderFor(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := loadWithRetry(loader, uri, defaultRetryConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func loadWithRetry(loader SourceLoader, uri string, cfg RetryConfig) (io.ReadCloser, error) {
+	var lastErr error
+	delay := cfg.BaseDelay
+	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+		rc, err := loader.Load(uri)
+		if err == nil {
+			return rc, nil
+		}
+		lastErr = err
+		if attempt < cfg.MaxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return nil, fmt.Errorf("failed to load %s after %d attempts: %w", uri, cfg.MaxAttempts, lastErr)
+}
+
+func sourceLoaderFor(uri string) (SourceLoader, error) {
+	switch sourceScheme(uri) {
+	case "file":
+		return fileSourceLoader{}, nil
+	case "http", "https":
+		return httpSourceLoader{client: http.DefaultClient}, nil
+	case "s3":
+		return s3SourceLoader{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source scheme in %q", uri)
+	}
+}
+
+type fileSourceLoader struct{}
+
+func (fileSourceLoader) Load(uri string) (io.ReadCloser, error) {
+	return os.Open(strings.TrimPrefix(uri, "file://"))
+}
+
+type httpSourceLoader struct {
+	client *http.Client
+}
+
+func (l httpSourceLoader) Load(uri string) (io.ReadCloser, error) {
+	resp, err := l.client.Get(uri)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", uri, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, uri)
+	}
+	return resp.Body, nil
+}
+
+// s3SourceLoader fetches s3:// objects with a plain HTTPS GET against the
+// S3 REST endpoint, so no AWS SDK dependency is needed. When
+// AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY are set, the request is signed
+// with AWS Signature Version 4 (via signS3Request); otherwise it's sent
+// unsigned, which works for objects in public buckets. s3Getter, when set,
+// overrides this entirely - useful for tests that don't want to hit S3.
+type s3SourceLoader struct {
+	client *http.Client
+}
+
+var s3Getter func(bucket, key string) (io.ReadCloser, error)
+
+func (l s3SourceLoader) Load(uri string) (io.ReadCloser, error) {
+	if s3Getter != nil {
+		bucket, key, err := parseS3URI(uri)
+		if err != nil {
+			return nil, err
+		}
+		return s3Getter(bucket, key)
+	}
+
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	region := firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	req, err := http.NewRequest(http.MethodGet, s3Endpoint(region, bucket, key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request for %s: %w", uri, err)
+	}
+
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey != "" && secretKey != "" {
+		signS3Request(req, region, accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"))
+	}
+
+	client := l.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", uri, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d fetching %s: %s", resp.StatusCode, uri, string(body))
+	}
+	return resp.Body, nil
+}
+
+// s3Endpoint builds the path-style S3 REST URL for bucket/key in region,
+// routing the join through net/url so characters like '#' and '?' in key
+// are percent-encoded into the path rather than parsed as a fragment or
+// query string.
+func s3Endpoint(region, bucket, key string) string {
+	u := url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("s3.%s.amazonaws.com", region),
+		Path:   "/" + bucket + "/" + key,
+	}
+	return u.String()
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// signS3Request signs req in place for the S3 service using AWS Signature
+// Version 4 (https://docs.aws.amazon.com/general/latest/gr/sigv4-signed-request-type.html),
+// setting req.Host plus the X-Amz-Date, X-Amz-Content-Sha256, (optional)
+// X-Amz-Security-Token and Authorization headers. req.Body must be empty,
+// which holds for the GET-only requests this loader issues.
+func signS3Request(req *http.Request, region, accessKey, secretKey, sessionToken string) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(nil)
+
+	req.Host = req.URL.Host
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	var canonicalHeaders strings.Builder
+	fmt.Fprintf(&canonicalHeaders, "host:%s\n", req.URL.Host)
+	fmt.Fprintf(&canonicalHeaders, "x-amz-content-sha256:%s\n", payloadHash)
+	fmt.Fprintf(&canonicalHeaders, "x-amz-date:%s\n", amzDate)
+	if sessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+		fmt.Fprintf(&canonicalHeaders, "x-amz-security-token:%s\n", sessionToken)
+	}
+	signedHeadersStr := strings.Join(signedHeaders, ";")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders.String(),
+		signedHeadersStr,
+		payloadHash,
+	}, "\n")
+
+	credentialScope := strings.Join([]string{dateStamp, region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := s3SigningKey(secretKey, dateStamp, region)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	authHeader := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, credentialScope, signedHeadersStr, signature)
+	req.Header.Set("Authorization", authHeader)
+}
+
+func s3SigningKey(secretKey, dateStamp, region string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secretKey), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, "s3")
+	return hmacSHA256(kService, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func parseS3URI(uri string) (bucket, key string, err error) {
+	trimmed := strings.TrimPrefix(uri, "s3://")
+	parts := strings.SplitN(trimmed, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid s3 URI %q: expected s3://bucket/key", uri)
+	}
+	return parts[0], parts[1], nil
+}
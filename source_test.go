@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseS3URI(t *testing.T) {
+	tests := []struct {
+		uri        string
+		wantBucket string
+		wantKey    string
+		wantErr    bool
+	}{
+		{"s3://my-bucket/path/to/object.json", "my-bucket", "path/to/object.json", false},
+		{"s3://my-bucket/object.json", "my-bucket", "object.json", false},
+		{"s3://my-bucket/", "", "", true},
+		{"s3://", "", "", true},
+		{"not-s3-at-all", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			bucket, key, err := parseS3URI(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q, got none", tt.uri)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", tt.uri, err)
+			}
+			if bucket != tt.wantBucket || key != tt.wantKey {
+				t.Fatalf("parseS3URI(%q) = (%q, %q), want (%q, %q)", tt.uri, bucket, key, tt.wantBucket, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestS3EndpointEscapesSpecialCharactersInKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"object.json", "https://s3.us-east-1.amazonaws.com/my-bucket/object.json"},
+		{"path/to/a file#1.json", "https://s3.us-east-1.amazonaws.com/my-bucket/path/to/a%20file%231.json"},
+		{"report?v2.json", "https://s3.us-east-1.amazonaws.com/my-bucket/report%3Fv2.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got := s3Endpoint("us-east-1", "my-bucket", tt.key)
+			if got != tt.want {
+				t.Fatalf("s3Endpoint(...) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignS3RequestSetsAuthorizationHeader(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://s3.us-east-1.amazonaws.com/my-bucket/object.json", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	signS3Request(req, "us-east-1", "AKIAEXAMPLE", "secretExample", "")
+
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/") {
+		t.Fatalf("Authorization header missing expected credential prefix: %q", auth)
+	}
+	if !strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+		t.Fatalf("Authorization header missing expected signed headers: %q", auth)
+	}
+	if req.Header.Get("X-Amz-Date") == "" {
+		t.Fatalf("expected X-Amz-Date header to be set")
+	}
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		t.Fatalf("expected X-Amz-Content-Sha256 header to be set")
+	}
+}
+
+func TestSignS3RequestIncludesSessionToken(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://s3.us-east-1.amazonaws.com/my-bucket/object.json", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	signS3Request(req, "us-east-1", "AKIAEXAMPLE", "secretExample", "sessionTokenValue")
+
+	if req.Header.Get("X-Amz-Security-Token") != "sessionTokenValue" {
+		t.Fatalf("expected X-Amz-Security-Token header to be set")
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "x-amz-security-token") {
+		t.Fatalf("expected session token to be part of SignedHeaders: %q", req.Header.Get("Authorization"))
+	}
+}
+
+func TestS3SourceLoaderUsesOverrideGetter(t *testing.T) {
+	t.Cleanup(func() { s3Getter = nil })
+
+	s3Getter = func(bucket, key string) (io.ReadCloser, error) {
+		if bucket != "my-bucket" || key != "object.json" {
+			t.Fatalf("unexpected bucket/key: %s/%s", bucket, key)
+		}
+		return io.NopCloser(strings.NewReader(`{"ok":true}`)), nil
+	}
+
+	rc, err := (s3SourceLoader{}).Load("s3://my-bucket/object.json")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(data) != `{"ok":true}` {
+		t.Fatalf("unexpected body: %s", data)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// shouldStream reports whether file1/file2 are big enough local files to use
+// the streaming comparator instead of loading both fully into memory. Source
+// URIs and glob/merge inputs always go through the in-memory path, since
+// streaming needs a single seekable pair of files on disk.
+func shouldStream(file1, file2 string, thresholdBytes int64) bool {
+	if thresholdBytes <= 0 {
+		return false
+	}
+	if isSourceURI(file1) || isSourceURI(file2) || isGlobPattern(file1) || isGlobPattern(file2) {
+		return false
+	}
+	size1, err1 := fileSize(file1)
+	size2, err2 := fileSize(file2)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return size1 >= thresholdBytes || size2 >= thresholdBytes
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// streamCompareToCSV diffs two JSON files by walking both token streams in
+// lockstep with json.Decoder, writing each difference straight to csvPath as
+// it's found. Neither file's full tree is ever materialized, so memory use
+// stays bounded regardless of file size.
+//
+// The tradeoff: this only supports the "csv" output format (jsonpatch and
+// jsondiff need the full decoded tree to build their output), and it assumes
+// both files encode object keys in the same order, which holds for files
+// produced by the same generator (e.g. two exports of the same API, two
+// renders of the same template) but not for hand-edited JSON with reordered
+// keys - those will report spurious key-order diffs rather than true
+// structural equality.
+func streamCompareToCSV(file1Path, file2Path, csvPath string, config *Config) (CompareResult, error) {
+	f1, err := os.Open(file1Path)
+	if err != nil {
+		return CompareResult{}, fmt.Errorf("error opening %s: %w", file1Path, err)
+	}
+	defer f1.Close()
+
+	f2, err := os.Open(file2Path)
+	if err != nil {
+		return CompareResult{}, fmt.Errorf("error opening %s: %w", file2Path, err)
+	}
+	defer f2.Close()
+
+	outFile, err := os.Create(csvPath)
+	if err != nil {
+		return CompareResult{}, fmt.Errorf("error creating CSV file: %w", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+	if err := writer.Write([]string{"Path", config.Input.FileName1, config.Input.FileName2}); err != nil {
+		return CompareResult{}, fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	dec1 := json.NewDecoder(bufio.NewReader(f1))
+	dec2 := json.NewDecoder(bufio.NewReader(f2))
+
+	result := CompareResult{}
+	if err := streamWalk(dec1, dec2, "", &result, writer); err != nil {
+		return result, err
+	}
+	writer.Flush()
+	return result, writer.Error()
+}
+
+func streamWalk(dec1, dec2 *json.Decoder, path string, result *CompareResult, w *csv.Writer) error {
+	t1, err1 := dec1.Token()
+	t2, err2 := dec2.Token()
+	if err1 == io.EOF || err2 == io.EOF {
+		return nil
+	}
+	if err1 != nil {
+		return fmt.Errorf("error reading token at %s: %w", path, err1)
+	}
+	if err2 != nil {
+		return fmt.Errorf("error reading token at %s: %w", path, err2)
+	}
+
+	d1, isDelim1 := t1.(json.Delim)
+	d2, isDelim2 := t2.(json.Delim)
+
+	if isDelim1 && isDelim2 && d1 == d2 {
+		switch d1 {
+		case '{':
+			return streamWalkObject(dec1, dec2, path, result, w)
+		case '[':
+			return streamWalkArray(dec1, dec2, path, result, w)
+		}
+		return nil
+	}
+
+	if isDelim1 != isDelim2 || d1 != d2 || t1 != t2 {
+		return streamEmitDiff(path, tokenString(t1), tokenString(t2), result, w)
+	}
+	return nil
+}
+
+func streamWalkObject(dec1, dec2 *json.Decoder, path string, result *CompareResult, w *csv.Writer) error {
+	for dec1.More() || dec2.More() {
+		if dec1.More() != dec2.More() {
+			return streamEmitDiff(path, fmt.Sprintf("%d keys", boolToInt(dec1.More())), fmt.Sprintf("%d keys", boolToInt(dec2.More())), result, w)
+		}
+
+		key1, err := dec1.Token()
+		if err != nil {
+			return fmt.Errorf("error reading key at %s: %w", path, err)
+		}
+		key2, err := dec2.Token()
+		if err != nil {
+			return fmt.Errorf("error reading key at %s: %w", path, err)
+		}
+
+		k1, _ := key1.(string)
+		k2, _ := key2.(string)
+		newPath := joinPath(path, k1)
+
+		if k1 != k2 {
+			if err := streamEmitDiff(newPath, k1, k2, result, w); err != nil {
+				return err
+			}
+		}
+		if err := streamWalk(dec1, dec2, newPath, result, w); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing '}' on both streams.
+	if _, err := dec1.Token(); err != nil {
+		return fmt.Errorf("error closing object at %s: %w", path, err)
+	}
+	if _, err := dec2.Token(); err != nil {
+		return fmt.Errorf("error closing object at %s: %w", path, err)
+	}
+	return nil
+}
+
+func streamWalkArray(dec1, dec2 *json.Decoder, path string, result *CompareResult, w *csv.Writer) error {
+	for i := 0; dec1.More() || dec2.More(); i++ {
+		if dec1.More() != dec2.More() {
+			return streamEmitDiff(fmt.Sprintf("%s[%d]", path, i), fmt.Sprintf("%v", dec1.More()), fmt.Sprintf("%v", dec2.More()), result, w)
+		}
+		newPath := fmt.Sprintf("%s[%d]", path, i)
+		if err := streamWalk(dec1, dec2, newPath, result, w); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing ']' on both streams.
+	if _, err := dec1.Token(); err != nil {
+		return fmt.Errorf("error closing array at %s: %w", path, err)
+	}
+	if _, err := dec2.Token(); err != nil {
+		return fmt.Errorf("error closing array at %s: %w", path, err)
+	}
+	return nil
+}
+
+func streamEmitDiff(path, v1, v2 string, result *CompareResult, w *csv.Writer) error {
+	result.FalseCount++
+	return w.Write([]string{path, v1, v2})
+}
+
+func tokenString(t json.Token) string {
+	if t == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v", t)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v2"
 )
 
@@ -15,32 +25,252 @@ type Config struct {
 		FilePath  string `yaml:"file_path"`
 		FileName1 string `yaml:"file_name_1"`
 		FileName2 string `yaml:"file_name_2"`
+		// MergeStrategy controls how multiple files matched by a glob on one
+		// side are combined before comparison: "concat" (default, each file
+		// becomes one element of an array) or "deep_merge" (maps merge key by
+		// key, arrays are appended together).
+		MergeStrategy string `yaml:"merge_strategy"`
 	} `yaml:"input"`
+	Format  string         `yaml:"format"`
+	Compare CompareOptions `yaml:"compare"`
+	// StreamThresholdBytes switches to the streaming comparator (see
+	// streaming.go) for local file inputs at or above this size, trading the
+	// jsonpatch/jsondiff formats and in-memory DiffArray for bounded memory
+	// use. Zero (the default) keeps the in-memory readFileAndUnmarshal path
+	// for every input.
+	StreamThresholdBytes int64 `yaml:"stream_threshold_bytes"`
+	// FailOn selects which diff categories make the process exit non-zero:
+	// "any" (default), "added", "removed", or "changed". See ci.go.
+	FailOn string `yaml:"fail_on"`
+	// Reporter selects a CI-facing result format written alongside the usual
+	// output: "junit" (JUnit XML) or "tap". Leave unset to skip it.
+	Reporter string `yaml:"reporter"`
+	// AssertionMode turns on per-path assertion checking (see Assertions)
+	// instead of the default "fail on any top-level key with a diff"
+	// behavior used for JUnit/TAP test cases.
+	AssertionMode bool `yaml:"assertion_mode"`
+	// Assertions are per-path rules checked when AssertionMode is true. At
+	// least one is required in that case.
+	Assertions []Assertion `yaml:"assertions"`
+}
+
+// Assertion is a single CI assertion rule: the value at Path must either
+// differ between the two inputs (AssertMustDiffer) or be equal
+// (AssertMustEqual).
+type Assertion struct {
+	Path string `yaml:"path"`
+	Rule string `yaml:"rule"`
+}
+
+const (
+	AssertMustDiffer = "must_differ"
+	AssertMustEqual  = "must_equal"
+)
+
+// OutputFormat enumerates the supported diff report formats.
+type OutputFormat string
+
+const (
+	FormatText      OutputFormat = "text"
+	FormatCSV       OutputFormat = "csv"
+	FormatJSONPatch OutputFormat = "jsonpatch"
+	FormatJSONDiff  OutputFormat = "jsondiff"
+)
+
+// JSONPatchOp is a single RFC 6902 JSON Patch operation.
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
 }
 
 type CompareResult struct {
 	FalseCount int
 	DiffArray  [][]string
+	// Diffs mirrors DiffArray with each entry's category attached, so CI
+	// consumers (--fail-on, assertions, JUnit/TAP reporters) can reason about
+	// additions/removals/changes without re-parsing DiffArray's strings.
+	Diffs []DiffRecord
+}
+
+// DiffCategory classifies a single DiffRecord for --fail-on filtering and
+// reporting.
+type DiffCategory string
+
+const (
+	DiffAdded   DiffCategory = "added"   // present in the second input only
+	DiffRemoved DiffCategory = "removed" // present in the first input only
+	DiffChanged DiffCategory = "changed" // present in both, but differs
+)
+
+// DiffRecord is one difference found by the comparison core.
+type DiffRecord struct {
+	Path     string
+	OldValue string
+	NewValue string
+	Category DiffCategory
+}
+
+// record appends a diff to both DiffArray (the CSV-facing shape) and Diffs
+// (the categorized shape), and bumps FalseCount.
+func (r *CompareResult) record(path, oldValue, newValue string, category DiffCategory) {
+	r.FalseCount++
+	r.DiffArray = append(r.DiffArray, []string{path, oldValue, newValue})
+	r.Diffs = append(r.Diffs, DiffRecord{Path: path, OldValue: oldValue, NewValue: newValue, Category: category})
 }
 
 func main() {
+	formatFlag := flag.String("format", "", "output format: text, csv, jsonpatch, jsondiff (overrides config.yml)")
+	failOnFlag := flag.String("fail-on", "", "exit non-zero on this diff category: any, added, removed, changed (overrides config.yml)")
+	reporterFlag := flag.String("reporter", "", "CI reporter to also write: junit, tap (overrides config.yml)")
+	applyPatchFlag := flag.String("apply-patch", "", "apply a JSON Patch file to an input file and print the result: --apply-patch patch.json:input.json")
+	flag.Parse()
+
+	if *applyPatchFlag != "" {
+		if err := runApplyPatch(*applyPatchFlag); err != nil {
+			log.Fatalf("Error applying patch: %v", err)
+		}
+		return
+	}
+
 	config, err := loadConfig("config.yml")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if config.AssertionMode && len(config.Assertions) == 0 {
+		log.Fatalf("assertion_mode is enabled but no assertions are configured in config.yml")
+	}
+
+	format := resolveFormat(config.Format, *formatFlag)
+	failOn := resolveFailOn(config.FailOn, *failOnFlag)
+	reporter := resolveReporter(config.Reporter, *reporterFlag)
+
 	file1 := config.Input.FilePath + config.Input.FileName1
 	file2 := config.Input.FilePath + config.Input.FileName2
-	diff, result, err := compareJSON(file1, file2)
-	if err != nil {
-		log.Fatalf("Error comparing JSON files: %v", err)
+
+	if isDir(file1) && isDir(file2) {
+		aggregate, dirCases, err := compareDirectories(file1, file2, &config)
+		if err != nil {
+			log.Fatalf("Error comparing directories: %v", err)
+		}
+		runCIGate(&config, failOn, reporter, aggregate, dirCases)
+		return
+	}
+
+	if shouldStream(file1, file2, config.StreamThresholdBytes) {
+		// The streaming comparator doesn't categorize diffs or build a tree,
+		// so --fail-on only ever sees FalseCount (behaves like "any") and
+		// assertion_mode can't be evaluated here; warn rather than silently
+		// ignoring the configured behavior. The reporter still gets a
+		// minimal one-case report so a configured JUnit/TAP consumer keeps
+		// getting a file.
+		result, err := streamCompareToCSV(file1, file2, "comparison_result.csv", &config)
+		if err != nil {
+			log.Fatalf("Error streaming comparison: %v", err)
+		}
+		fmt.Printf("Streamed comparison complete: %d difference(s) written to comparison_result.csv\n", result.FalseCount)
+		if failOn != "" && failOn != "any" {
+			log.Printf("Warning: streaming mode doesn't categorize diffs; --fail-on=%s behaves like --fail-on=any", failOn)
+		}
+		if config.AssertionMode {
+			log.Printf("Warning: streaming mode doesn't build a diff tree; assertion_mode is ignored for this comparison")
+		}
+		cases := []TestCase{{Name: "streamed comparison", Passed: result.FalseCount == 0, Message: fmt.Sprintf("%d difference(s) found", result.FalseCount)}}
+		if err := writeReport(reporter, cases); err != nil {
+			log.Fatalf("Error writing %s report: %v", reporter, err)
+		}
+		if result.FalseCount > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var diff string
+	var result CompareResult
+	var obj1, obj2 interface{}
+
+	if !isSourceURI(file1) && !isSourceURI(file2) && (isGlobPattern(file1) || isGlobPattern(file2)) {
+		obj1, err = loadInputSide(file1, config.Input.MergeStrategy)
+		if err != nil {
+			log.Fatalf("Error loading %s: %v", file1, err)
+		}
+		obj2, err = loadInputSide(file2, config.Input.MergeStrategy)
+		if err != nil {
+			log.Fatalf("Error loading %s: %v", file2, err)
+		}
+		diff, result = compareObjects(obj1, obj2, config.Compare)
+	} else {
+		diff, result, obj1, obj2, err = compareJSON(file1, file2, config.Compare)
+		if err != nil {
+			log.Fatalf("Error comparing JSON files: %v", err)
+		}
 	}
 	fmt.Print(diff)
 
-	// Generate CSV file with comparison result
-	err = writeCSV("comparison_result.csv", result.DiffArray, &config)
-	if err != nil {
-		log.Fatalf("Error writing CSV: %v", err)
+	switch format {
+	case FormatJSONPatch:
+		patch := generateJSONPatch(obj1, obj2)
+		if err := writeJSONFile("patch.json", patch); err != nil {
+			log.Fatalf("Error writing JSON Patch: %v", err)
+		}
+	case FormatJSONDiff:
+		jsondiff := generateJSONDiff(obj1, obj2)
+		if jsondiff == nil {
+			jsondiff = map[string]interface{}{}
+		}
+		if err := writeJSONFile("jsondiff.json", jsondiff); err != nil {
+			log.Fatalf("Error writing JSON diff: %v", err)
+		}
+	case FormatText:
+		// The diff was already printed to stdout above; text mode is
+		// stdout-only and writes no report file.
+	default:
+		// Generate CSV file with comparison result
+		err = writeCSV("comparison_result.csv", result.DiffArray, &config)
+		if err != nil {
+			log.Fatalf("Error writing CSV: %v", err)
+		}
 	}
+
+	runCIGate(&config, failOn, reporter, result, topLevelKeyTestCases(obj1, obj2, result.Diffs))
+}
+
+// runCIGate applies assertion mode (if enabled) or the plain --fail-on
+// category count to result.Diffs, writes the selected reporter's output
+// file, and exits non-zero on failure. defaultCases is used for the
+// non-assertion reporter output; assertion mode builds its own cases from
+// config.Assertions instead. Shared by the single-file/glob path and the
+// directory-mode path so both honor the same CI gate.
+func runCIGate(config *Config, failOn, reporter string, result CompareResult, defaultCases []TestCase) {
+	var cases []TestCase
+	failed := false
+	if config.AssertionMode {
+		cases = assertionTestCases(evaluateAssertions(result.Diffs, config.Assertions))
+		failed = anyFailed(cases)
+	} else {
+		cases = defaultCases
+		failed = countFailingDiffs(result.Diffs, failOn) > 0
+	}
+
+	if err := writeReport(reporter, cases); err != nil {
+		log.Fatalf("Error writing %s report: %v", reporter, err)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// resolveFormat picks the effective output format, preferring the CLI flag
+// over config.yml and falling back to the historical CSV behavior.
+func resolveFormat(configFormat, flagFormat string) OutputFormat {
+	if flagFormat != "" {
+		return OutputFormat(flagFormat)
+	}
+	if configFormat != "" {
+		return OutputFormat(configFormat)
+	}
+	return FormatCSV
 }
 
 func loadConfig(filePath string) (Config, error) {
@@ -58,69 +288,415 @@ func loadConfig(filePath string) (Config, error) {
 	return config, nil
 }
 
+// readFileAndUnmarshal decodes a file into interface{} regardless of whether
+// it's JSON, YAML, or TOML, so that compareMaps can operate on any of them
+// uniformly. The format is picked from the file extension and, failing that,
+// sniffed from the content.
 func readFileAndUnmarshal(filePath string) (interface{}, error) {
+	data, err := readInputBytes(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch detectFormat(filePath, data) {
+	case formatJSON:
+		var obj interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("error unmarshalling JSON from file %s: %w", filePath, err)
+		}
+		return obj, nil
+	case formatTOML:
+		var obj map[string]interface{}
+		if err := toml.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("error unmarshalling TOML from file %s: %w", filePath, err)
+		}
+		return normalizeDecodedValue(obj), nil
+	default:
+		return decodeYAMLDocuments(filePath, data)
+	}
+}
+
+// readInputBytes fetches filePath's raw bytes, transparently supporting
+// http(s)://, s3://, and file:// URIs via loadSourceBytes in addition to
+// plain local paths.
+func readInputBytes(filePath string) ([]byte, error) {
+	if isSourceURI(filePath) {
+		data, err := loadSourceBytes(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s: %w", filePath, err)
+		}
+		return data, nil
+	}
+
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
 	}
+	return data, nil
+}
+
+type fileFormat string
+
+const (
+	formatJSON fileFormat = "json"
+	formatYAML fileFormat = "yaml"
+	formatTOML fileFormat = "toml"
+)
+
+// detectFormat picks a fileFormat for filePath, first from its extension and
+// then, if that's inconclusive, from the content itself.
+func detectFormat(filePath string, data []byte) fileFormat {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	}
+	return sniffFormat(data)
+}
+
+// sniffFormat guesses a format from content when the extension doesn't say,
+// in the spirit of Hugo's metadecoders: JSON is recognized by its leading
+// brace/bracket, TOML by "key = value" / "[section]" lines, and everything
+// else is treated as YAML, which is permissive enough to cover plain text
+// and is also a superset of JSON.
+func sniffFormat(data []byte) fileFormat {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return formatYAML
+	}
+	switch trimmed[0] {
+	case '{', '[':
+		return formatJSON
+	}
+	if looksLikeTOML(trimmed) {
+		return formatTOML
+	}
+	return formatYAML
+}
+
+func looksLikeTOML(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			return true
+		}
+		if idx := strings.Index(line, "="); idx > 0 && !strings.Contains(line[:idx], ":") {
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// decodeYAMLDocuments decodes every YAML document in filePath. A single
+// document is returned as-is; multiple documents (separated by "---") are
+// merged into one tree, with later documents' top-level keys overriding
+// earlier ones, since compareJSON only ever diffs one tree per file. Callers
+// that need document-by-document diffing should split the file beforehand.
+func decodeYAMLDocuments(filePath string, data []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []interface{}
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling YAML from file %s: %w", filePath, err)
+		}
+		docs = append(docs, normalizeDecodedValue(doc))
+	}
+
+	switch len(docs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return docs[0], nil
+	default:
+		return mergeYAMLDocuments(docs), nil
+	}
+}
 
-	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
-		return nil, fmt.Errorf("error unmarshalling JSON from file %s: %w", filePath, err)
+func mergeYAMLDocuments(docs []interface{}) interface{} {
+	merged := map[string]interface{}{}
+	for _, doc := range docs {
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			// Non-map documents can't be merged key-by-key; last one wins.
+			return docs[len(docs)-1]
+		}
+		for k, v := range m {
+			merged[k] = v
+		}
 	}
+	return merged
+}
 
-	return obj, nil
+// normalizeDecodedValue recursively converts YAML's map[interface{}]interface{}
+// into map[string]interface{} (so compareMaps works unchanged) and widens
+// YAML/TOML integer types to float64 to match encoding/json's number
+// representation, so that e.g. a YAML "3" and a JSON "3" compare equal.
+func normalizeDecodedValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[fmt.Sprintf("%v", k)] = normalizeDecodedValue(val)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[k] = normalizeDecodedValue(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = normalizeDecodedValue(val)
+		}
+		return out
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	default:
+		return v
+	}
 }
 
-func compareJSON(file1Path, file2Path string) (string, CompareResult, error) {
+func compareJSON(file1Path, file2Path string, compareCfg CompareOptions) (string, CompareResult, interface{}, interface{}, error) {
 	obj1, err := readFileAndUnmarshal(file1Path)
 	if err != nil {
-		return "", CompareResult{}, err
+		return "", CompareResult{}, nil, nil, err
 	}
 
 	obj2, err := readFileAndUnmarshal(file2Path)
 	if err != nil {
-		return "", CompareResult{}, err
+		return "", CompareResult{}, nil, nil, err
 	}
 
+	diff, result := compareObjects(obj1, obj2, compareCfg)
+	return diff, result, obj1, obj2, nil
+}
+
+// compareObjects runs the comparison core over two already-decoded trees. It
+// backs compareJSON and is called directly for glob/merge input, which has no
+// single pair of file paths to hand to compareJSON.
+func compareObjects(obj1, obj2 interface{}, compareCfg CompareOptions) (string, CompareResult) {
 	result := CompareResult{}
-	diff := compareMaps(obj1, obj2, "", &result)
+	opts := prepareCompareOptions(compareCfg)
+	diff := compareMaps(obj1, obj2, "", &result, opts)
 	if diff != "" {
-		return fmt.Sprintf("Differences found:\n%s", diff), result, nil
+		return fmt.Sprintf("Differences found:\n%s", diff), result
+	}
+	return "JSON files are identical", result
+}
+
+// CompareOptions configures the comparison core's behavior and is populated
+// from the `compare` section of config.yml.
+type CompareOptions struct {
+	// IgnorePaths are glob patterns (matched against dot/bracket paths, e.g.
+	// "metadata.annotations.*" or "users[*].id") whose subtrees are skipped.
+	IgnorePaths []string `yaml:"ignore_paths"`
+	// NumericTolerance lets two float64 values be treated as equal when they
+	// fall within an absolute or relative epsilon of each other.
+	NumericTolerance NumericTolerance `yaml:"numeric_tolerance"`
+	// ArrayCompare selects how arrays are diffed: "ordered" (default,
+	// index-by-index), "set" (unordered, matched by structural equality), or
+	// "keyed" (matched by an identity field named in ArrayKeys).
+	ArrayCompare string `yaml:"array_compare"`
+	// ArrayKeys maps the dot-path of an array (e.g. "users") to the field
+	// used to identify its elements (e.g. "id") when ArrayCompare is "keyed".
+	ArrayKeys map[string]string `yaml:"array_keys"`
+	// TypeCoercion treats equivalent scalars of different JSON types (e.g.
+	// the string "1" and the number 1) as equal.
+	TypeCoercion bool `yaml:"type_coercion"`
+}
+
+// NumericTolerance expresses how close two numbers must be to be considered
+// equal. A zero value disables tolerance (exact comparison).
+type NumericTolerance struct {
+	Absolute float64 `yaml:"absolute"`
+	Relative float64 `yaml:"relative"`
+}
+
+const (
+	ArrayCompareOrdered = "ordered"
+	ArrayCompareSet     = "set"
+	ArrayCompareKeyed   = "keyed"
+)
+
+// preparedCompareOptions is CompareOptions with its ignore-path globs
+// precompiled once per comparison run, rather than on every recursive call.
+type preparedCompareOptions struct {
+	ignorePatterns   []*regexp.Regexp
+	numericTolerance NumericTolerance
+	arrayCompare     string
+	arrayKeys        map[string]string
+	typeCoercion     bool
+}
+
+func prepareCompareOptions(cfg CompareOptions) *preparedCompareOptions {
+	opts := &preparedCompareOptions{
+		numericTolerance: cfg.NumericTolerance,
+		arrayCompare:     cfg.ArrayCompare,
+		arrayKeys:        cfg.ArrayKeys,
+		typeCoercion:     cfg.TypeCoercion,
+	}
+	for _, pattern := range cfg.IgnorePaths {
+		opts.ignorePatterns = append(opts.ignorePatterns, globToRegexp(pattern))
+	}
+	return opts
+}
+
+func (o *preparedCompareOptions) shouldIgnore(path string) bool {
+	if o == nil || path == "" {
+		return false
+	}
+	for _, re := range o.ignorePatterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *preparedCompareOptions) arrayCompareMode(path string) string {
+	if o == nil || o.arrayCompare == "" {
+		return ArrayCompareOrdered
+	}
+	return o.arrayCompare
+}
+
+func (o *preparedCompareOptions) arrayKeyField(path string) string {
+	if o == nil || o.arrayKeys == nil {
+		return ""
 	}
+	return o.arrayKeys[path]
+}
 
-	return "JSON files are identical", result, nil
+func (o *preparedCompareOptions) valuesEqual(v1, v2 interface{}) bool {
+	if v1 == v2 {
+		return true
+	}
+	if o == nil {
+		return false
+	}
+	if f1, ok1 := v1.(float64); ok1 {
+		if f2, ok2 := v2.(float64); ok2 && withinTolerance(f1, f2, o.numericTolerance) {
+			return true
+		}
+	}
+	if o.typeCoercion && coerceScalar(v1) == coerceScalar(v2) {
+		return true
+	}
+	return false
 }
 
-func compareMaps(m1, m2 interface{}, path string, result *CompareResult) string {
+func withinTolerance(a, b float64, tol NumericTolerance) bool {
+	if tol.Absolute <= 0 && tol.Relative <= 0 {
+		return false
+	}
+	diff := math.Abs(a - b)
+	if tol.Absolute > 0 && diff <= tol.Absolute {
+		return true
+	}
+	if tol.Relative > 0 {
+		denom := math.Max(math.Abs(a), math.Abs(b))
+		if denom == 0 {
+			return diff == 0
+		}
+		if diff/denom <= tol.Relative {
+			return true
+		}
+	}
+	return false
+}
+
+// coerceScalar normalizes a scalar to a comparable string so that, e.g., the
+// number 1 and the string "1" can be recognized as equal under type coercion.
+func coerceScalar(v interface{}) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return t
+	case bool:
+		return strconv.FormatBool(t)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// globToRegexp compiles a glob pattern (where "*" matches any run of
+// characters and "?" matches exactly one) into an anchored regexp.
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+func compareMaps(m1, m2 interface{}, path string, result *CompareResult, opts *preparedCompareOptions) string {
+	if opts.shouldIgnore(path) {
+		return ""
+	}
+
 	switch v1 := m1.(type) {
 	case map[string]interface{}:
-		return compareMapObjects(v1, m2, path, result)
+		return compareMapObjects(v1, m2, path, result, opts)
 	case []interface{}:
-		return compareArrayObjects(v1, m2, path, result)
+		return compareArrayObjects(v1, m2, path, result, opts)
 	default:
-		return comparePrimitiveObjects(v1, m2, path, result)
+		return comparePrimitiveObjects(v1, m2, path, result, opts)
 	}
 }
 
-func compareMapObjects(m1 map[string]interface{}, m2 interface{}, path string, result *CompareResult) string {
+func compareMapObjects(m1 map[string]interface{}, m2 interface{}, path string, result *CompareResult, opts *preparedCompareOptions) string {
 	v2, ok := m2.(map[string]interface{})
 	if !ok {
-		result.FalseCount++
+		result.record(path, fmt.Sprintf("%T", m1), fmt.Sprintf("%T", m2), DiffChanged)
 		return fmt.Sprintf("Type mismatch at %s: expected map[string]interface{} got %T\n", path, m2)
 	}
 
 	var diff string
 	for key, val1 := range m1 {
 		newPath := joinPath(path, key)
+		if opts.shouldIgnore(newPath) {
+			continue
+		}
 		val2, ok := v2[key]
 		if !ok {
 			diff += fmt.Sprintf("Key '%s' missing in second map at %s\n", key, newPath)
-			result.FalseCount++
-			result.DiffArray = append(result.DiffArray, []string{newPath, "Missing", ""})
+			result.record(newPath, "Missing", "", DiffRemoved)
 			continue
 		}
-		subDiff := compareMaps(val1, val2, newPath, result)
+		subDiff := compareMaps(val1, val2, newPath, result, opts)
 		if subDiff != "" {
 			diff += subDiff
 		}
@@ -128,30 +704,46 @@ func compareMapObjects(m1 map[string]interface{}, m2 interface{}, path string, r
 	for key := range v2 {
 		if _, ok := m1[key]; !ok {
 			newPath := joinPath(path, key)
+			if opts.shouldIgnore(newPath) {
+				continue
+			}
 			diff += fmt.Sprintf("Key '%s' missing in first map at %s\n", key, newPath)
-			result.FalseCount++
-			result.DiffArray = append(result.DiffArray, []string{newPath, "", "Missing"})
+			result.record(newPath, "", "Missing", DiffAdded)
 		}
 	}
 	return diff
 }
 
-func compareArrayObjects(a1 []interface{}, m2 interface{}, path string, result *CompareResult) string {
+func compareArrayObjects(a1 []interface{}, m2 interface{}, path string, result *CompareResult, opts *preparedCompareOptions) string {
 	v2, ok := m2.([]interface{})
 	if !ok {
-		result.FalseCount++
+		result.record(path, fmt.Sprintf("%T", a1), fmt.Sprintf("%T", m2), DiffChanged)
 		return fmt.Sprintf("Type mismatch at %s: expected []interface{} got %T\n", path, m2)
 	}
 
+	switch opts.arrayCompareMode(path) {
+	case ArrayCompareSet:
+		return compareArraySet(a1, v2, path, result)
+	case ArrayCompareKeyed:
+		if keyField := opts.arrayKeyField(path); keyField != "" {
+			return compareArrayKeyed(a1, v2, path, keyField, result, opts)
+		}
+		fallthrough
+	default:
+		return compareArrayOrdered(a1, v2, path, result, opts)
+	}
+}
+
+func compareArrayOrdered(a1, v2 []interface{}, path string, result *CompareResult, opts *preparedCompareOptions) string {
 	if len(a1) != len(v2) {
-		result.FalseCount++
+		result.record(path, strconv.Itoa(len(a1)), strconv.Itoa(len(v2)), DiffChanged)
 		return fmt.Sprintf("Length mismatch at %s: %d != %d\n", path, len(a1), len(v2))
 	}
 
 	var diff string
 	for i := range a1 {
 		newPath := fmt.Sprintf("%s[%d]", path, i)
-		subDiff := compareMaps(a1[i], v2[i], newPath, result)
+		subDiff := compareMaps(a1[i], v2[i], newPath, result, opts)
 		if subDiff != "" {
 			diff += subDiff
 		}
@@ -159,14 +751,113 @@ func compareArrayObjects(a1 []interface{}, m2 interface{}, path string, result *
 	return diff
 }
 
-func comparePrimitiveObjects(v1, v2 interface{}, path string, result *CompareResult) string {
-	if v1 != v2 {
-		result.FalseCount++
-		diff := fmt.Sprintf("Value mismatch at %s: %v != %v\n", path, v1, v2)
-		result.DiffArray = append(result.DiffArray, []string{path, fmt.Sprintf("%v", v1), fmt.Sprintf("%v", v2)})
-		return diff
+// compareArraySet compares two arrays as unordered multisets: each element is
+// matched against its first unconsumed structural twin on the other side, and
+// only genuinely unmatched elements are reported as additions/removals.
+func compareArraySet(a1, a2 []interface{}, path string, result *CompareResult) string {
+	canon2 := make([]string, len(a2))
+	for i, e := range a2 {
+		canon2[i] = canonicalJSON(e)
 	}
-	return ""
+	used := make([]bool, len(a2))
+
+	var diff string
+	for i, e := range a1 {
+		ce := canonicalJSON(e)
+		matched := false
+		for j := range a2 {
+			if used[j] || canon2[j] != ce {
+				continue
+			}
+			used[j] = true
+			matched = true
+			break
+		}
+		if !matched {
+			newPath := fmt.Sprintf("%s[%d]", path, i)
+			diff += fmt.Sprintf("Element at %s present in first array but not in second (unordered compare)\n", newPath)
+			result.record(newPath, fmt.Sprintf("%v", e), "", DiffRemoved)
+		}
+	}
+	for j, e := range a2 {
+		if used[j] {
+			continue
+		}
+		newPath := fmt.Sprintf("%s[%d]", path, j)
+		diff += fmt.Sprintf("Element at %s present in second array but not in first (unordered compare)\n", newPath)
+		result.record(newPath, "", fmt.Sprintf("%v", e), DiffAdded)
+	}
+	return diff
+}
+
+// compareArrayKeyed diffs two arrays of objects by identity, matching
+// elements on keyField instead of by index, and reports insertions/deletions
+// rather than a length mismatch.
+func compareArrayKeyed(a1, a2 []interface{}, path, keyField string, result *CompareResult, opts *preparedCompareOptions) string {
+	index1, order1 := indexByKey(a1, keyField)
+	index2, order2 := indexByKey(a2, keyField)
+
+	var diff string
+	for _, key := range order1 {
+		newPath := fmt.Sprintf("%s[%s=%s]", path, keyField, key)
+		val2, ok := index2[key]
+		if !ok {
+			diff += fmt.Sprintf("Element with %s=%s missing in second array at %s\n", keyField, key, newPath)
+			result.record(newPath, "Present", "Missing", DiffRemoved)
+			continue
+		}
+		subDiff := compareMaps(index1[key], val2, newPath, result, opts)
+		if subDiff != "" {
+			diff += subDiff
+		}
+	}
+	for _, key := range order2 {
+		if _, ok := index1[key]; !ok {
+			newPath := fmt.Sprintf("%s[%s=%s]", path, keyField, key)
+			diff += fmt.Sprintf("Element with %s=%s missing in first array at %s\n", keyField, key, newPath)
+			result.record(newPath, "Missing", "Present", DiffAdded)
+		}
+	}
+	return diff
+}
+
+// indexByKey builds a lookup of array elements by their keyField value,
+// preserving first-seen order so diffs are reported deterministically.
+func indexByKey(a []interface{}, keyField string) (map[string]interface{}, []string) {
+	index := make(map[string]interface{}, len(a))
+	order := make([]string, 0, len(a))
+	for _, e := range a {
+		obj, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keyVal, ok := obj[keyField]
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%v", keyVal)
+		if _, exists := index[key]; !exists {
+			order = append(order, key)
+		}
+		index[key] = e
+	}
+	return index, order
+}
+
+func canonicalJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func comparePrimitiveObjects(v1, v2 interface{}, path string, result *CompareResult, opts *preparedCompareOptions) string {
+	if opts.valuesEqual(v1, v2) {
+		return ""
+	}
+	result.record(path, fmt.Sprintf("%v", v1), fmt.Sprintf("%v", v2), DiffChanged)
+	return fmt.Sprintf("Value mismatch at %s: %v != %v\n", path, v1, v2)
 }
 
 func joinPath(base, key string) string {
@@ -197,3 +888,544 @@ func writeCSV(filePath string, data [][]string, config *Config) error {
 	}
 	return nil
 }
+
+func writeJSONFile(filePath string, v interface{}) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating JSON file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("error encoding JSON to file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// generateJSONPatch walks obj1 and obj2 in lockstep and produces an RFC 6902
+// JSON Patch document (add/remove/replace operations) that transforms obj1
+// into obj2. Paths are RFC 6901 JSON Pointers.
+func generateJSONPatch(obj1, obj2 interface{}) []JSONPatchOp {
+	var ops []JSONPatchOp
+	appendPatchOps(obj1, obj2, nil, &ops)
+	return ops
+}
+
+func appendPatchOps(v1, v2 interface{}, segments []string, ops *[]JSONPatchOp) {
+	m1, ok1 := v1.(map[string]interface{})
+	m2, ok2 := v2.(map[string]interface{})
+	if ok1 && ok2 {
+		for key, val1 := range m1 {
+			childSegments := append(append([]string{}, segments...), key)
+			val2, ok := m2[key]
+			if !ok {
+				*ops = append(*ops, JSONPatchOp{Op: "remove", Path: toJSONPointer(childSegments)})
+				continue
+			}
+			appendPatchOps(val1, val2, childSegments, ops)
+		}
+		for key, val2 := range m2 {
+			if _, ok := m1[key]; !ok {
+				childSegments := append(append([]string{}, segments...), key)
+				*ops = append(*ops, JSONPatchOp{Op: "add", Path: toJSONPointer(childSegments), Value: val2})
+			}
+		}
+		return
+	}
+
+	a1, ok1 := v1.([]interface{})
+	a2, ok2 := v2.([]interface{})
+	if ok1 && ok2 && len(a1) == len(a2) {
+		for i := range a1 {
+			childSegments := append(append([]string{}, segments...), strconv.Itoa(i))
+			appendPatchOps(a1[i], a2[i], childSegments, ops)
+		}
+		return
+	}
+
+	if !valuesDeepEqual(v1, v2) {
+		*ops = append(*ops, JSONPatchOp{Op: "replace", Path: toJSONPointer(segments), Value: v2})
+	}
+}
+
+// valuesDeepEqual compares two decoded JSON values for equality. Unlike
+// Go's == operator, this never panics on uncomparable dynamic types (e.g.
+// two []interface{} of different length reaching this point as a whole-value
+// replacement) because it never evaluates v1 != v2 directly.
+func valuesDeepEqual(v1, v2 interface{}) bool {
+	return canonicalJSON(v1) == canonicalJSON(v2)
+}
+
+// toJSONPointer encodes a slice of raw path segments as an RFC 6901 JSON
+// Pointer, escaping "~" and "/" within each segment.
+func toJSONPointer(segments []string) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = escapePointerToken(s)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
+
+func escapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return token
+}
+
+func unescapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~1", "/")
+	token = strings.ReplaceAll(token, "~0", "~")
+	return token
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", pointer)
+	}
+	rawSegments := strings.Split(pointer[1:], "/")
+	segments := make([]string, len(rawSegments))
+	for i, s := range rawSegments {
+		segments[i] = unescapePointerToken(s)
+	}
+	return segments, nil
+}
+
+// runApplyPatch implements `--apply-patch patch.json:input.json`: it reads a
+// JSON Patch document produced by --format jsonpatch, applies it to input.json,
+// and prints the resulting JSON to stdout, completing the diff/apply round trip.
+func runApplyPatch(spec string) error {
+	patchPath, inputPath, ok := strings.Cut(spec, ":")
+	if !ok {
+		return fmt.Errorf("invalid --apply-patch value %q: expected patch.json:input.json", spec)
+	}
+
+	patchData, err := os.ReadFile(patchPath)
+	if err != nil {
+		return fmt.Errorf("error reading patch file %s: %w", patchPath, err)
+	}
+	var patch []JSONPatchOp
+	if err := json.Unmarshal(patchData, &patch); err != nil {
+		return fmt.Errorf("error unmarshalling JSON Patch from %s: %w", patchPath, err)
+	}
+
+	doc, err := readFileAndUnmarshal(inputPath)
+	if err != nil {
+		return err
+	}
+
+	patched, err := applyPatch(doc, patch)
+	if err != nil {
+		return fmt.Errorf("error applying patch: %w", err)
+	}
+
+	out, err := json.MarshalIndent(patched, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling patched result: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// applyPatch applies an RFC 6902 JSON Patch document to doc and returns the
+// resulting value. An op at the root path replaces doc outright; every other
+// op mutates the containers along its path in place, including doc itself
+// when it is a map.
+func applyPatch(doc interface{}, patch []JSONPatchOp) (interface{}, error) {
+	for _, op := range patch {
+		segments, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(segments) == 0 {
+			switch op.Op {
+			case "add", "replace":
+				doc = op.Value
+			case "remove":
+				doc = nil
+			default:
+				return nil, fmt.Errorf("unsupported patch op %q at root", op.Op)
+			}
+			continue
+		}
+
+		doc, err = applyPatchOp(doc, segments, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func applyPatchOp(container interface{}, segments []string, op JSONPatchOp) (interface{}, error) {
+	key := segments[0]
+	rest := segments[1:]
+
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			switch op.Op {
+			case "add", "replace":
+				c[key] = op.Value
+			case "remove":
+				delete(c, key)
+			default:
+				return nil, fmt.Errorf("unsupported patch op %q at %s", op.Op, op.Path)
+			}
+			return c, nil
+		}
+		child, ok := c[key]
+		if !ok {
+			return nil, fmt.Errorf("path %s not found: key %q missing", op.Path, key)
+		}
+		newChild, err := applyPatchOp(child, rest, op)
+		if err != nil {
+			return nil, err
+		}
+		c[key] = newChild
+		return c, nil
+
+	case []interface{}:
+		idx, appending, err := parsePatchArrayIndex(key, len(c))
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			switch op.Op {
+			case "add":
+				if appending {
+					c = append(c, op.Value)
+				} else {
+					c = append(c, nil)
+					copy(c[idx+1:], c[idx:])
+					c[idx] = op.Value
+				}
+			case "replace":
+				if idx >= len(c) {
+					return nil, fmt.Errorf("array index %d out of range at %s: replace requires an existing element", idx, op.Path)
+				}
+				c[idx] = op.Value
+			case "remove":
+				if idx >= len(c) {
+					return nil, fmt.Errorf("array index %d out of range at %s: remove requires an existing element", idx, op.Path)
+				}
+				c = append(c[:idx], c[idx+1:]...)
+			default:
+				return nil, fmt.Errorf("unsupported patch op %q at %s", op.Op, op.Path)
+			}
+			return c, nil
+		}
+		if idx >= len(c) {
+			return nil, fmt.Errorf("array index %d out of range at %s", idx, op.Path)
+		}
+		newChild, err := applyPatchOp(c[idx], rest, op)
+		if err != nil {
+			return nil, err
+		}
+		c[idx] = newChild
+		return c, nil
+
+	default:
+		return nil, fmt.Errorf("cannot navigate into %T at %s", container, op.Path)
+	}
+}
+
+func parsePatchArrayIndex(token string, length int) (idx int, appending bool, err error) {
+	if token == "-" {
+		return length, true, nil
+	}
+	idx, err = strconv.Atoi(token)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid array index %q", token)
+	}
+	if idx < 0 || idx > length {
+		return 0, false, fmt.Errorf("array index %d out of range", idx)
+	}
+	return idx, false, nil
+}
+
+// generateJSONDiff builds a compact nested diff mirroring the shape of v1/v2,
+// with {"__old":..,"__new":..} leaves wherever the two values differ. It
+// returns nil when v1 and v2 are equal.
+func generateJSONDiff(v1, v2 interface{}) interface{} {
+	m1, ok1 := v1.(map[string]interface{})
+	m2, ok2 := v2.(map[string]interface{})
+	if ok1 && ok2 {
+		out := map[string]interface{}{}
+		for key, val1 := range m1 {
+			val2, ok := m2[key]
+			if !ok {
+				out[key] = map[string]interface{}{"__old": val1, "__new": nil}
+				continue
+			}
+			if d := generateJSONDiff(val1, val2); d != nil {
+				out[key] = d
+			}
+		}
+		for key, val2 := range m2 {
+			if _, ok := m1[key]; !ok {
+				out[key] = map[string]interface{}{"__old": nil, "__new": val2}
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+
+	a1, ok1 := v1.([]interface{})
+	a2, ok2 := v2.([]interface{})
+	if ok1 && ok2 && len(a1) == len(a2) {
+		out := make([]interface{}, len(a1))
+		changed := false
+		for i := range a1 {
+			d := generateJSONDiff(a1[i], a2[i])
+			out[i] = d
+			changed = changed || d != nil
+		}
+		if !changed {
+			return nil
+		}
+		return out
+	}
+
+	if !valuesDeepEqual(v1, v2) {
+		return map[string]interface{}{"__old": v1, "__new": v2}
+	}
+	return nil
+}
+
+// isDir reports whether path exists and is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// isGlobPattern reports whether path contains glob meta-characters.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// loadInputSide resolves one side of a comparison that may be a plain file
+// or a glob pattern. A glob matching a single file behaves like a plain
+// file; a glob matching several files is combined per mergeStrategy
+// ("concat", the default, or "deep_merge").
+func loadInputSide(pathSpec, mergeStrategy string) (interface{}, error) {
+	if !isGlobPattern(pathSpec) {
+		return readFileAndUnmarshal(pathSpec)
+	}
+
+	matches, err := filepath.Glob(pathSpec)
+	if err != nil {
+		return nil, fmt.Errorf("error expanding glob %s: %w", pathSpec, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("glob %s matched no files", pathSpec)
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 1 {
+		return readFileAndUnmarshal(matches[0])
+	}
+
+	objs := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		obj, err := readFileAndUnmarshal(match)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+
+	if mergeStrategy == "deep_merge" {
+		return deepMergeAll(objs), nil
+	}
+	return objs, nil
+}
+
+// deepMergeAll folds a sequence of decoded documents into one tree: maps
+// merge key by key (later documents win on scalar conflicts), and arrays at
+// the same path are appended together rather than replaced.
+func deepMergeAll(objs []interface{}) interface{} {
+	var merged interface{}
+	for _, obj := range objs {
+		merged = deepMergeValue(merged, obj)
+	}
+	return merged
+}
+
+func deepMergeValue(dst, src interface{}) interface{} {
+	if dst == nil {
+		return src
+	}
+
+	if dstMap, ok := dst.(map[string]interface{}); ok {
+		if srcMap, ok := src.(map[string]interface{}); ok {
+			out := make(map[string]interface{}, len(dstMap))
+			for k, v := range dstMap {
+				out[k] = v
+			}
+			for k, v := range srcMap {
+				if existing, ok := out[k]; ok {
+					out[k] = deepMergeValue(existing, v)
+				} else {
+					out[k] = v
+				}
+			}
+			return out
+		}
+	}
+
+	if dstArr, ok := dst.([]interface{}); ok {
+		if srcArr, ok := src.([]interface{}); ok {
+			out := make([]interface{}, 0, len(dstArr)+len(srcArr))
+			out = append(out, dstArr...)
+			out = append(out, srcArr...)
+			return out
+		}
+	}
+
+	// Scalars, or a type mismatch: the later document wins.
+	return src
+}
+
+// compareDirectories pairs files across dir1/dir2 by relative path, writes
+// the usual per-pair/summary CSV reports, and also returns an aggregate
+// CompareResult (diff paths prefixed "relPath:path"; a file present on only
+// one side is recorded under its bare relPath) plus one TestCase per
+// pair, so the caller can run directory mode through the same CI gate
+// (--fail-on, assertions, exit code, JUnit/TAP) as the single-file case.
+func compareDirectories(dir1, dir2 string, config *Config) (CompareResult, []TestCase, error) {
+	files1, err := collectRelativeFiles(dir1)
+	if err != nil {
+		return CompareResult{}, nil, fmt.Errorf("error walking %s: %w", dir1, err)
+	}
+	files2, err := collectRelativeFiles(dir2)
+	if err != nil {
+		return CompareResult{}, nil, fmt.Errorf("error walking %s: %w", dir2, err)
+	}
+
+	if err := os.MkdirAll(comparisonReportsDir, 0o755); err != nil {
+		return CompareResult{}, nil, fmt.Errorf("error creating %s: %w", comparisonReportsDir, err)
+	}
+
+	var summary [][]string
+	var aggregate CompareResult
+	var cases []TestCase
+	for _, rel := range sortedUnionKeys(files1, files2) {
+		_, in1 := files1[rel]
+		_, in2 := files2[rel]
+
+		switch {
+		case in1 && in2:
+			f1 := filepath.Join(dir1, rel)
+			f2 := filepath.Join(dir2, rel)
+			_, result, _, _, err := compareJSON(f1, f2, config.Compare)
+			if err != nil {
+				summary = append(summary, []string{rel, "", fmt.Sprintf("error: %v", err)})
+				cases = append(cases, TestCase{Name: rel, Passed: false, Message: err.Error()})
+				continue
+			}
+			reportPath := filepath.Join(comparisonReportsDir, sanitizeReportName(rel)+".csv")
+			if err := writeCSV(reportPath, result.DiffArray, config); err != nil {
+				return aggregate, cases, err
+			}
+			status := "match"
+			if result.FalseCount > 0 {
+				status = "diff"
+			}
+			summary = append(summary, []string{rel, strconv.Itoa(result.FalseCount), status})
+			for _, d := range result.Diffs {
+				aggregate.record(rel+":"+d.Path, d.OldValue, d.NewValue, d.Category)
+			}
+			cases = append(cases, TestCase{Name: rel, Passed: result.FalseCount == 0, Message: fmt.Sprintf("%d difference(s), see %s", result.FalseCount, reportPath)})
+		case in1 && !in2:
+			summary = append(summary, []string{rel, "", "only_in_" + filepath.Base(dir1)})
+			aggregate.record(rel, "Present", "Missing", DiffRemoved)
+			cases = append(cases, TestCase{Name: rel, Passed: false, Message: "only present in " + dir1})
+		case in2 && !in1:
+			summary = append(summary, []string{rel, "", "only_in_" + filepath.Base(dir2)})
+			aggregate.record(rel, "Missing", "Present", DiffAdded)
+			cases = append(cases, TestCase{Name: rel, Passed: false, Message: "only present in " + dir2})
+		}
+	}
+
+	if err := writeSummaryCSV(filepath.Join(comparisonReportsDir, "summary.csv"), summary); err != nil {
+		return aggregate, cases, err
+	}
+	return aggregate, cases, nil
+}
+
+const comparisonReportsDir = "comparison_reports"
+
+// collectRelativeFiles walks dir and returns the set of regular files in it,
+// keyed by their path relative to dir.
+func collectRelativeFiles(dir string) (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = struct{}{}
+		return nil
+	})
+	return files, err
+}
+
+func sortedUnionKeys(a, b map[string]struct{}) []string {
+	union := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		union[k] = struct{}{}
+	}
+	for k := range b {
+		union[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(union))
+	for k := range union {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sanitizeReportName turns a relative file path into a safe, flat filename.
+func sanitizeReportName(rel string) string {
+	replacer := strings.NewReplacer(string(filepath.Separator), "_", "/", "_", ".", "_")
+	return replacer.Replace(rel)
+}
+
+func writeSummaryCSV(filePath string, rows [][]string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Pair", "DiffCount", "Status"}); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing CSV row: %w", err)
+		}
+	}
+	return nil
+}